@@ -0,0 +1,32 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package base
+
+// DebugFlags holds the values of the -d=name=val compiler debug
+// flags. It's one struct, extended in place by every feature that
+// wants its own -d= flag rather than inventing a parallel registry;
+// it currently holds PureCheck and PureEvalTimeout.
+type DebugFlags struct {
+	// PureCheck controls whether //go:pure pragmas are statically
+	// verified (see ir.VerifyPure) before being trusted. Set via
+	// -d=purecheck=0 to fall back to trusting the pragma blindly, as
+	// an escape hatch if the verifier itself has a bug.
+	PureCheck int `help:"verify //go:pure pragmas before trusting them (default on)"`
+
+	// PureEvalTimeout overrides, in seconds, how long the pure-eval
+	// helper program fallback is allowed to run before it's killed.
+	// Zero means use the built-in default. Set via
+	// -d=pureevaltimeout=N.
+	PureEvalTimeout int `help:"seconds before a pure-eval helper program is killed (default 5)"`
+}
+
+// Debug holds the current values of the -d=name=val debug flags.
+var Debug DebugFlags
+
+func init() {
+	// -d=purecheck defaults to on; every other flag here defaults to
+	// its zero value.
+	Debug.PureCheck = 1
+}
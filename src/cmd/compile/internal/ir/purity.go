@@ -0,0 +1,163 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ir
+
+import (
+	"sync"
+
+	"cmd/compile/internal/base"
+)
+
+// Pure is the PragmaFlag bit set on Func.Pragma by a //go:pure
+// comment. It occupies the top bit of the uint16 so it can't collide
+// with whichever lower bits the rest of the compiler's pragma flags
+// (Noinline, Nosplit, and so on) are already using.
+const Pure PragmaFlag = 1 << 15
+
+// VerifyPure checks that fn's body actually satisfies the contract a
+// //go:pure pragma makes: that it's safe to execute during the
+// compile, with no observable side effects and a result that depends
+// only on its arguments. It's the pure-function analogue of the escape
+// analysis pass - a structural walk of the IR, not a trust of whatever
+// the author wrote on the pragma.
+//
+// A function fails verification if its body, or the body of anything
+// it calls, does any of:
+//
+//   - reads a package-level variable
+//   - calls a function that is not itself //go:pure and verified
+//   - performs a channel operation, goroutine spawn, defer, or recover
+//   - uses unsafe, cgo, or reflection
+//   - ranges over a map, whose iteration order is nondeterministic
+//
+// VerifyPure reports the first violation found, or ("", true) if the
+// function checks out. Results are memoized in pureVerifyCache, keyed
+// by *Func, so repeated calls (once per call site, potentially) don't
+// re-walk the body.
+//
+// The -d=purecheck=0 debug flag disables this check entirely, causing
+// every //go:pure pragma to be trusted as before; it exists as an
+// escape hatch for working around a verifier bug, not for routine use.
+func VerifyPure(fn *Func) (reason string, ok bool) {
+	if base.Debug.PureCheck == 0 {
+		return "", true
+	}
+	reason = verifyPure(fn, map[*Func]bool{})
+	return reason, reason == ""
+}
+
+// verifyPure does the actual walk, threading inFlight - the set of
+// functions whose verification is already in progress higher up this
+// same call stack - through every nested call instead of starting a
+// fresh seen-set each time. Two //go:pure functions that call each
+// other (A -> B -> A) would otherwise recurse forever: neither gets
+// memoized into pureVerifyCache until its own outermost call returns,
+// so a fresh verifier for B would call a fresh verifier for A, which
+// would call a fresh verifier for B, and so on. Sharing inFlight across
+// the whole chain lets the second visit to A short-circuit instead.
+func verifyPure(fn *Func, inFlight map[*Func]bool) string {
+	if inFlight[fn] {
+		// Already being verified further up this same call chain;
+		// assume pure for the purposes of breaking the cycle and let
+		// the outer call's result be the one that sticks.
+		return ""
+	}
+	if cached, found := pureVerifyCache.Load(fn); found {
+		return cached.(string)
+	}
+
+	inFlight[fn] = true
+	v := &pureVerifier{fn: fn, inFlight: inFlight}
+	reason := v.checkBody(fn.Body)
+
+	pureVerifyCache.Store(fn, reason)
+	return reason
+}
+
+// pureVerifyCache memoizes VerifyPure results across the whole
+// compilation. It's a sync.Map rather than a field on Func because
+// functions can be verified concurrently (the compiler parallelizes
+// across declarations) and because it keeps this cache local to the
+// purity checker instead of growing Func's footprint for every
+// function that will never see a //go:pure pragma.
+var pureVerifyCache sync.Map // map[*Func]string (violation reason, "" if pure)
+
+type pureVerifier struct {
+	fn       *Func
+	inFlight map[*Func]bool
+}
+
+// checkBody walks a statement list looking for the first disqualifying
+// construct, returning a human-readable reason or "" if none is found.
+func (v *pureVerifier) checkBody(stmts []Node) string {
+	reason := ""
+	VisitList(stmts, func(n Node) {
+		if reason != "" {
+			return
+		}
+		if r := v.checkNode(n); r != "" {
+			reason = r
+		}
+	})
+	return reason
+}
+
+func (v *pureVerifier) checkNode(n Node) string {
+	switch n := n.(type) {
+	case *SendStmt, *SelectStmt, *RangeStmt:
+		if rs, ok := n.(*RangeStmt); ok {
+			if rs.X.Type() != nil && rs.X.Type().IsMap() {
+				return "ranges over a map (nondeterministic iteration order)"
+			}
+			if rs.X.Type() == nil || !rs.X.Type().IsChan() {
+				return ""
+			}
+		}
+		return "performs a channel operation"
+
+	case *GoDeferStmt:
+		if n.Op() == OGO {
+			return "spawns a goroutine"
+		}
+		return "uses defer"
+
+	case *UnaryExpr:
+		if n.Op() == ORECOVER {
+			return "calls recover"
+		}
+		if n.Op() == ORECV || n.Op() == OCLOSE {
+			return "performs a channel operation"
+		}
+
+	case *Name:
+		// A reference to a named package-level constant is already
+		// folded to an OLITERAL wherever it's used, long before this
+		// walk runs, so any *Name reaching here with Class PEXTERN is
+		// necessarily a real package variable, not a constant.
+		if n.Op() == ONAME && n.Class == PEXTERN {
+			return "reads a package-level variable " + n.Sym().Name
+		}
+
+	case *CallExpr:
+		callee, ok := StaticValue(n.X).(*Name)
+		if !ok || callee.Func == nil {
+			// Can't statically resolve the callee (interface method,
+			// closure value, etc.) - conservatively reject.
+			return "calls a function that cannot be statically verified as pure"
+		}
+		if callee.Func.Pragma&Pure == 0 {
+			return "calls " + callee.Sym().Name + ", which is not //go:pure"
+		}
+		if reason := verifyPure(callee.Func, v.inFlight); reason != "" {
+			return "calls " + callee.Sym().Name + ": " + reason
+		}
+
+	case *ConvExpr:
+		if n.Op() == OCONVNOP && n.Type() != nil && n.Type().IsUnsafePtr() {
+			return "uses unsafe"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,311 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go/constant"
+	"go/token"
+	"internal/buildcfg"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/ir"
+)
+
+// pureEvalActionKind distinguishes pure-eval cache entries from the
+// rest of GOCACHE's compile and link action entries. It's the "p" the
+// request asked for: a new, disjoint namespace under the existing
+// cache directory, so a pure-eval result can never be confused with
+// (or collide with) a compile action's output.
+const pureEvalActionKind = "p"
+
+// pureEvalCacheGet looks up a previously computed result for calling
+// fn with args on this GOOS/GOARCH. It returns the cached ir.Node and
+// true on a hit.
+//
+// The cache key covers fn's package path and name, a hash of the IR
+// of fn and of every function it calls (see pureFuncSourceHash, so
+// editing the callee invalidates every result derived from the old
+// body), the constant argument values, and the target GOOS/GOARCH,
+// since folded results for OS/arch-dependent functions must not be
+// shared across cross-compiles. A function with neither a body nor an
+// inline body in this compilation adds only its package path and name
+// to that hash, so a changed implementation of such a function does
+// not change the key.
+func pureEvalCacheGet(fn *ir.Func, args []ir.Node) (ir.Node, bool) {
+	dir := pureEvalCacheDir()
+	if dir == "" {
+		return nil, false
+	}
+
+	key, ok := pureEvalCacheKey(fn, args)
+	if !ok {
+		return nil, false
+	}
+	data, err := os.ReadFile(pureEvalCachePath(dir, key))
+	if err != nil {
+		return nil, false
+	}
+
+	n := decodePureResult(fn, string(data))
+	if n == nil {
+		return nil, false
+	}
+
+	if base.Flag.LowerM > 1 {
+		fmt.Printf("pure-eval cache hit for %s\n", ir.PkgFuncName(fn))
+	}
+	return n, true
+}
+
+// pureEvalCachePut stores the result of evaluating fn(args) for reuse
+// by any later compilation - of this package or any other - that makes
+// the identical call.
+func pureEvalCachePut(fn *ir.Func, args []ir.Node, result ir.Node) {
+	dir := pureEvalCacheDir()
+	if dir == "" {
+		return
+	}
+
+	encoded := encodePureResult(result)
+	if encoded == "" {
+		return
+	}
+
+	key, ok := pureEvalCacheKey(fn, args)
+	if !ok {
+		return
+	}
+	path := pureEvalCachePath(dir, key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	// Best effort: a failed write just means the next compile redoes
+	// the evaluation instead of reusing a stale or partial entry.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(encoded), 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
+
+func pureEvalCacheDir() string {
+	gocache := os.Getenv("GOCACHE")
+	if gocache == "" || gocache == "off" {
+		return ""
+	}
+	return filepath.Join(gocache, pureEvalActionKind)
+}
+
+func pureEvalCachePath(dir, key string) string {
+	return filepath.Join(dir, key[:2], key)
+}
+
+// pureEvalCacheKey hashes everything the cached result depends on. It
+// reports ok=false when an argument isn't foldable into a pureValue at
+// all (shouldn't happen given allArgsConstant already screened the
+// call, but this runs again independently and must not assume
+// arg.Val() is safe to call - args can be *ir.CompLitExpr composites,
+// not just OLITERAL, since composite constant arguments were added).
+func pureEvalCacheKey(fn *ir.Func, args []ir.Node) (string, bool) {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n", fn.Sym().Pkg.Path, fn.Sym().Name)
+	fmt.Fprintf(h, "%s\n", pureFuncSourceHash(fn))
+	for _, arg := range args {
+		v, ok := constCompositeValue(arg)
+		if !ok {
+			return "", false
+		}
+		hashPureValue(h, v)
+	}
+	fmt.Fprintf(h, "%s/%s\n", buildcfg.GOOS, buildcfg.GOARCH)
+	return hex.EncodeToString(h.Sum(nil)), true
+}
+
+// hashPureValue writes a stable representation of a pureValue - scalar
+// or composite - to h, so that two equal argument trees always hash
+// the same way regardless of how they're nested.
+//
+// Float scalars are hashed via their exact numerator/denominator
+// rather than String(), which rounds to 6 significant digits: two
+// distinct float arguments that happen to format the same way at that
+// precision would otherwise hash identically, and a cache hit would
+// silently hand back a result computed for the wrong argument.
+func hashPureValue(h io.Writer, v pureValue) {
+	if v.isComposite() {
+		fmt.Fprintf(h, "(%d:", len(v.elems))
+		for _, e := range v.elems {
+			hashPureValue(h, e)
+		}
+		fmt.Fprint(h, ")")
+		return
+	}
+	if v.scalar.Kind() == constant.Float {
+		num, denom := exactFloatParts(v.scalar)
+		fmt.Fprintf(h, "%s=%s/%s\n", v.scalar.Kind(), num, denom)
+		return
+	}
+	// ExactString, not String: String abbreviates long strings with
+	// "...", which would let two distinct string arguments collide.
+	fmt.Fprintf(h, "%s=%s\n", v.scalar.Kind(), v.scalar.ExactString())
+}
+
+// exactFloatParts returns v's value as an exact integer numerator and
+// denominator, via go/constant's Num/Denom rather than String/
+// ExactString - Num and Denom are themselves Int-kind constant.Values,
+// whose String() is exact (unlike a Float's), so round-tripping
+// through them never loses precision the way formatting the float
+// itself to a fixed number of significant digits would.
+func exactFloatParts(v constant.Value) (num, denom string) {
+	return constant.Num(v).String(), constant.Denom(v).String()
+}
+
+// pureFuncSourceHash hashes fn's IR, and recursively the IR of every
+// function it calls, so that any change to its body - including one
+// that arrives transitively, through a callee whose body or inline
+// body is available here - produces a different cache key. A callee
+// with neither contributes only its package path and name, so a change
+// to its implementation alone leaves the key unchanged. Without the
+// recursion, a pure wrapper like
+//
+//	//go:pure
+//	func Upper(s string) string { return strings.ToUpper(s) }
+//
+// would keep serving a stale cached result forever after strings.ToUpper
+// itself changed, since Upper's own body never does.
+func pureFuncSourceHash(fn *ir.Func) string {
+	h := sha256.New()
+	hashFuncSource(h, fn, map[*ir.Func]bool{})
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// hashFuncSource writes fn's body - and the body of everything it
+// calls, transitively - into h. visited breaks cycles through mutually
+// (or directly) recursive calls, the same way VerifyPure's inFlight
+// set does.
+func hashFuncSource(h io.Writer, fn *ir.Func, visited map[*ir.Func]bool) {
+	if visited[fn] {
+		return
+	}
+	visited[fn] = true
+
+	body := fn.Body
+	fmt.Fprintf(h, "%s.%s:%v\n", fn.Sym().Pkg.Path, fn.Sym().Name, body)
+	if fn.Inl != nil {
+		fmt.Fprintf(h, "%s.%s#inl:%v\n", fn.Sym().Pkg.Path, fn.Sym().Name, fn.Inl.Body)
+		body = fn.Inl.Body
+	}
+
+	ir.VisitList(body, func(n ir.Node) {
+		call, ok := n.(*ir.CallExpr)
+		if !ok {
+			return
+		}
+		callee, ok := ir.StaticValue(call.X).(*ir.Name)
+		if !ok || callee.Func == nil {
+			return
+		}
+		hashFuncSource(h, callee.Func, visited)
+	})
+}
+
+// encodePureResult serializes a folded constant ir.Node to the small
+// text format stored on disk: one "kind:value" field per result,
+// separated by NUL so string values containing any other delimiter are
+// still unambiguous.
+func encodePureResult(n ir.Node) string {
+	vals, err := pureResultValues(n)
+	if err != nil {
+		return ""
+	}
+	fields := make([]string, len(vals))
+	for i, v := range vals {
+		switch v.Kind() {
+		case constant.String:
+			fields[i] = "S:" + constant.StringVal(v)
+		case constant.Int:
+			fields[i] = "i:" + v.String()
+		case constant.Float:
+			num, denom := exactFloatParts(v)
+			fields[i] = "f:" + num + "/" + denom
+		case constant.Bool:
+			fields[i] = "b:" + v.String()
+		default:
+			return ""
+		}
+	}
+	return strings.Join(fields, "\x00")
+}
+
+func pureResultValues(n ir.Node) ([]constant.Value, error) {
+	if n.Op() == ir.OLITERAL {
+		return []constant.Value{n.Val()}, nil
+	}
+	// Only the OCOMPLIT tuple foldPureResults builds for a multi-value
+	// result; a single array, slice or struct result is not encodable.
+	if cl, ok := n.(*ir.CompLitExpr); ok && cl.Op() == ir.OCOMPLIT {
+		vals := make([]constant.Value, len(cl.List))
+		for i, e := range cl.List {
+			if e.Op() != ir.OLITERAL {
+				return nil, fmt.Errorf("non-constant tuple element")
+			}
+			vals[i] = e.Val()
+		}
+		return vals, nil
+	}
+	return nil, fmt.Errorf("unsupported result shape")
+}
+
+func decodePureResult(fn *ir.Func, data string) ir.Node {
+	results := fn.Type().Results()
+	fields := strings.Split(data, "\x00")
+	if len(fields) != results.NumFields() {
+		return nil
+	}
+
+	vals := make([]pureValue, len(fields))
+	for i, f := range fields {
+		v, ok := decodePureField(f)
+		if !ok {
+			return nil
+		}
+		vals[i] = scalarValue(v)
+	}
+
+	if len(vals) == 1 {
+		return constToLit(vals[0].scalar, results.Field(0).Type)
+	}
+	return foldPureResults(fn, vals)
+}
+
+func decodePureField(f string) (constant.Value, bool) {
+	if len(f) < 2 || f[1] != ':' {
+		return nil, false
+	}
+	payload := f[2:]
+	switch f[0] {
+	case 'S':
+		return constant.MakeString(payload), true
+	case 'i':
+		return constant.MakeFromLiteral(payload, token.INT, 0), true
+	case 'f':
+		num, denom, ok := strings.Cut(payload, "/")
+		if !ok {
+			return nil, false
+		}
+		n := constant.MakeFromLiteral(num, token.INT, 0)
+		d := constant.MakeFromLiteral(denom, token.INT, 0)
+		return constant.BinaryOp(n, token.QUO, d), true
+	case 'b':
+		return constant.MakeBool(payload == "true"), true
+	}
+	return nil, false
+}
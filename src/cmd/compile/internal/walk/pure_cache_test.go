@@ -0,0 +1,94 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"crypto/sha256"
+	"go/constant"
+	"go/token"
+	"testing"
+
+	"cmd/compile/internal/types"
+)
+
+func TestEncodeDecodePureFieldRoundTrip(t *testing.T) {
+	tests := []constant.Value{
+		constant.MakeString("hello"),
+		constant.MakeInt64(-7),
+		constant.MakeBool(true),
+	}
+	for _, want := range tests {
+		var typ *types.Type
+		switch want.Kind() {
+		case constant.String:
+			typ = types.Types[types.TSTRING]
+		case constant.Int:
+			typ = types.Types[types.TINT]
+		case constant.Bool:
+			typ = types.Types[types.TBOOL]
+		}
+		field := encodePureResult(constToLit(want, typ))
+		got, ok := decodePureField(field)
+		if !ok {
+			t.Fatalf("decodePureField(%q) failed", field)
+		}
+		if !constant.Compare(got, token.EQL, want) {
+			t.Errorf("decodePureField(%q) = %v, want %v", field, got, want)
+		}
+	}
+}
+
+// TestEncodePureFieldFloatExact pins the review-flagged precision bug:
+// encoding must round-trip a float result exactly, not through
+// constant.Value.String()'s 6-significant-digit rounding, and two
+// distinct floats that happen to round to the same printed form must
+// not collide on the same encoded field.
+func TestEncodePureFieldFloatExact(t *testing.T) {
+	a := constant.MakeFromLiteral("0.1234567891", token.FLOAT, 0)
+	b := constant.MakeFromLiteral("0.1234567892", token.FLOAT, 0)
+
+	floatType := types.Types[types.TFLOAT64]
+	encA := encodePureResult(constToLit(a, floatType))
+	encB := encodePureResult(constToLit(b, floatType))
+	if encA == encB {
+		t.Fatalf("two distinct floats encoded identically: %q", encA)
+	}
+
+	got, ok := decodePureField(encA)
+	if !ok {
+		t.Fatalf("decodePureField(%q) failed", encA)
+	}
+	if !constant.Compare(got, token.EQL, a) {
+		t.Errorf("round-tripped float = %v, want exactly %v", got, a)
+	}
+}
+
+func TestHashPureValueStableAcrossEqualShapes(t *testing.T) {
+	a := pureValue{elems: []pureValue{
+		scalarValue(constant.MakeInt64(1)),
+		scalarValue(constant.MakeInt64(2)),
+	}}
+	b := pureValue{elems: []pureValue{
+		scalarValue(constant.MakeInt64(1)),
+		scalarValue(constant.MakeInt64(2)),
+	}}
+	c := pureValue{elems: []pureValue{
+		scalarValue(constant.MakeInt64(2)),
+		scalarValue(constant.MakeInt64(1)),
+	}}
+
+	if hashOf(a) != hashOf(b) {
+		t.Error("two structurally equal composite pureValues hashed differently")
+	}
+	if hashOf(a) == hashOf(c) {
+		t.Error("composite pureValues with a different element order hashed the same")
+	}
+}
+
+func hashOf(v pureValue) string {
+	h := sha256.New()
+	hashPureValue(h, v)
+	return string(h.Sum(nil))
+}
@@ -0,0 +1,229 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"fmt"
+	"go/constant"
+	"strings"
+
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/ir"
+	"cmd/compile/internal/types"
+)
+
+// pureValue is a constant-folded argument or result that pure-eval can
+// reason about. It's either a scalar (string/int/float/bool, as
+// before) or a composite: a fixed-length sequence of pureValues
+// standing in for an array, slice, or struct literal whose elements
+// are themselves all constant.
+//
+// Composites nest to any depth - elems may themselves be composite,
+// so nested structs-of-arrays and the like work, but a field of a
+// named non-constant-foldable type still isn't supported.
+type pureValue struct {
+	scalar constant.Value
+	elems  []pureValue
+}
+
+func scalarValue(v constant.Value) pureValue { return pureValue{scalar: v} }
+
+func (v pureValue) isComposite() bool { return v.scalar == nil && v.elems != nil }
+func (v pureValue) valid() bool       { return v.scalar != nil || v.elems != nil }
+
+// constCompositeValue recursively folds a constant expression - a
+// literal, or a composite literal built entirely out of constants -
+// into a pureValue, without needing any variable bindings. This is
+// what lets a caller pass []byte{'a', 'b', 'c'} or a fixed-shape
+// struct literal as a pure-eval argument. A conversion such as
+// []byte("abc") is neither a literal nor a composite literal, so it is
+// not folded.
+func constCompositeValue(n ir.Node) (pureValue, bool) {
+	switch n := n.(type) {
+	case *ir.BasicLit:
+		return scalarValue(n.Val()), true
+
+	case *ir.KeyExpr:
+		return constCompositeValue(n.Value)
+
+	case *ir.CompLitExpr:
+		elems := make([]pureValue, len(n.List))
+		for i, e := range n.List {
+			v, ok := constCompositeValue(e)
+			if !ok {
+				return pureValue{}, false
+			}
+			elems[i] = v
+		}
+		return pureValue{elems: elems}, true
+	}
+
+	if n.Op() == ir.OLITERAL {
+		return scalarValue(n.Val()), true
+	}
+	return pureValue{}, false
+}
+
+// isConstantArg reports whether n is usable as a pure-eval argument of
+// type typ: either a plain constant, or - new in this pass - an array,
+// slice, or struct composite literal whose elements all recursively
+// satisfy this same predicate.
+func isConstantArg(n ir.Node, typ *types.Type) bool {
+	if n.Op() == ir.OLITERAL {
+		return isScalarConstantKind(n.Val().Kind(), typ)
+	}
+
+	cl, ok := n.(*ir.CompLitExpr)
+	if !ok {
+		return false
+	}
+
+	switch {
+	case typ.IsArray() || typ.IsSlice():
+		elemType := typ.Elem()
+		for _, e := range cl.List {
+			if !isConstantArg(unkey(e), elemType) {
+				return false
+			}
+		}
+		return true
+
+	case typ.IsStruct():
+		fields := typ.Fields()
+		if len(cl.List) != len(fields) {
+			return false
+		}
+		for i, e := range cl.List {
+			if !isConstantArg(unkey(e), fields[i].Type) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func unkey(n ir.Node) ir.Node {
+	if k, ok := n.(*ir.KeyExpr); ok {
+		return k.Value
+	}
+	return n
+}
+
+func isScalarConstantKind(kind constant.Kind, typ *types.Type) bool {
+	switch {
+	case typ.IsString():
+		return kind == constant.String
+	case typ.IsInteger():
+		return kind == constant.Int
+	case typ.IsFloat():
+		return kind == constant.Float
+	case typ.IsBoolean():
+		return kind == constant.Bool
+	}
+	return false
+}
+
+// valueToNode folds a pureValue back into a typed ir.Node: a literal
+// for a scalar, or an *ir.CompLitExpr with SetTypecheck(1) for a
+// composite, recursing into its elements.
+func valueToNode(v pureValue, typ *types.Type) ir.Node {
+	if !v.isComposite() {
+		return constToLit(v.scalar, typ)
+	}
+
+	var elemType *types.Type
+	fields := ([]*types.Field)(nil)
+	if typ.IsStruct() {
+		fields = typ.Fields()
+	} else {
+		elemType = typ.Elem()
+	}
+
+	elems := make([]ir.Node, len(v.elems))
+	for i, e := range v.elems {
+		t := elemType
+		if fields != nil {
+			t = fields[i].Type
+		}
+		elems[i] = valueToNode(e, t)
+	}
+
+	cl := ir.NewCompLitExpr(base.Pos, compLitOp(typ), typ, elems)
+	cl.SetTypecheck(1)
+	return cl
+}
+
+// compLitOp picks the op a composite literal of typ typechecks to, so
+// a synthetic *ir.CompLitExpr built here looks the same as one the
+// parser would have produced.
+func compLitOp(typ *types.Type) ir.Op {
+	switch {
+	case typ.IsStruct():
+		return ir.OSTRUCTLIT
+	case typ.IsArray():
+		return ir.OARRAYLIT
+	case typ.IsSlice():
+		return ir.OSLICELIT
+	default:
+		return ir.OCOMPLIT
+	}
+}
+
+// renderArgLiteral renders a constant argument (scalar or composite)
+// as Go source text, for splicing into a generated helper program.
+func renderArgLiteral(n ir.Node, typ *types.Type) (string, bool) {
+	if n.Op() == ir.OLITERAL {
+		return renderScalarLiteral(n.Val())
+	}
+
+	cl, ok := n.(*ir.CompLitExpr)
+	if !ok {
+		return "", false
+	}
+
+	var parts []string
+	switch {
+	case typ.IsArray() || typ.IsSlice():
+		elemType := typ.Elem()
+		for _, e := range cl.List {
+			s, ok := renderArgLiteral(unkey(e), elemType)
+			if !ok {
+				return "", false
+			}
+			parts = append(parts, s)
+		}
+	case typ.IsStruct():
+		fields := typ.Fields()
+		for i, e := range cl.List {
+			s, ok := renderArgLiteral(unkey(e), fields[i].Type)
+			if !ok {
+				return "", false
+			}
+			parts = append(parts, s)
+		}
+	default:
+		return "", false
+	}
+
+	return fmt.Sprintf("%s{%s}", typ.String(), strings.Join(parts, ", ")), true
+}
+
+func renderScalarLiteral(val constant.Value) (string, bool) {
+	switch val.Kind() {
+	case constant.String:
+		return fmt.Sprintf("%q", constant.StringVal(val)), true
+	case constant.Int:
+		return val.String(), true
+	case constant.Float:
+		// String rounds a float to 6 significant digits; render the
+		// exact value as a quotient of two untyped float constants.
+		num, denom := exactFloatParts(val)
+		return "(" + num + ".0/" + denom + ".0)", true
+	case constant.Bool:
+		return fmt.Sprintf("%t", constant.BoolVal(val)), true
+	}
+	return "", false
+}
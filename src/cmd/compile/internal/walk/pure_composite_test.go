@@ -0,0 +1,71 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"go/constant"
+	"testing"
+
+	"cmd/compile/internal/types"
+)
+
+func TestIsScalarConstantKind(t *testing.T) {
+	tests := []struct {
+		kind constant.Kind
+		typ  *types.Type
+		want bool
+	}{
+		{constant.String, types.Types[types.TSTRING], true},
+		{constant.Int, types.Types[types.TINT], true},
+		{constant.Bool, types.Types[types.TBOOL], true},
+		{constant.String, types.Types[types.TINT], false},
+		{constant.Int, types.Types[types.TSTRING], false},
+	}
+	for _, tt := range tests {
+		if got := isScalarConstantKind(tt.kind, tt.typ); got != tt.want {
+			t.Errorf("isScalarConstantKind(%v, %v) = %v, want %v", tt.kind, tt.typ, got, tt.want)
+		}
+	}
+}
+
+func TestRenderScalarLiteral(t *testing.T) {
+	tests := []struct {
+		val  constant.Value
+		want string
+	}{
+		{constant.MakeString("abc"), `"abc"`},
+		{constant.MakeInt64(42), "42"},
+		{constant.MakeBool(true), "true"},
+	}
+	for _, tt := range tests {
+		got, ok := renderScalarLiteral(tt.val)
+		if !ok || got != tt.want {
+			t.Errorf("renderScalarLiteral(%v) = %q, %v, want %q, true", tt.val, got, ok, tt.want)
+		}
+	}
+}
+
+func TestPureValueComposite(t *testing.T) {
+	scalar := scalarValue(constant.MakeInt64(1))
+	if scalar.isComposite() {
+		t.Error("scalarValue should not report isComposite")
+	}
+	if !scalar.valid() {
+		t.Error("scalarValue should report valid")
+	}
+
+	composite := pureValue{elems: []pureValue{scalar}}
+	if !composite.isComposite() {
+		t.Error("a pureValue with elems and no scalar should report isComposite")
+	}
+	if !composite.valid() {
+		t.Error("a composite pureValue should report valid")
+	}
+
+	var zero pureValue
+	if zero.valid() {
+		t.Error("the zero pureValue should not report valid")
+	}
+}
@@ -6,9 +6,10 @@ package walk
 
 import (
 	"bytes"
+	"context"
 	"fmt"
-	"go/constant"
 	"internal/buildcfg"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -17,8 +18,18 @@ import (
 	"cmd/compile/internal/base"
 	"cmd/compile/internal/ir"
 	"cmd/compile/internal/types"
+	"cmd/compile/internal/types/errors"
 )
 
+// evaluatePureFunctionAtCompileTime attempts to fold a call to a
+// //go:pure function with all-constant arguments into a constant
+// result. It first tries to interpret the callee's IR directly
+// in-process (cheap, works for any function whose body or inline copy
+// is available, and is what makes this reproducible under
+// cross-compilation). Only when that's not possible - typically an
+// imported function with no inlinable body, e.g. one that's too large
+// or was built without inlining - does it fall back to running the
+// real implementation out-of-process via a throwaway helper program.
 func evaluatePureFunctionAtCompileTime(fn *ir.Func, args []ir.Node) ir.Node {
 	// Don't recursively evaluate if we're inside a helper program execution
 	if os.Getenv("GO_PURE_EVAL_HELPER") != "" {
@@ -29,6 +40,27 @@ func evaluatePureFunctionAtCompileTime(fn *ir.Func, args []ir.Node) ir.Node {
 		fmt.Printf("attempting compile-time evaluation of %s\n", ir.PkgFuncName(fn))
 	}
 
+	if reason, ok := ir.VerifyPure(fn); !ok {
+		base.ErrorfAt(fn.Pos(), errors.OtherError, "invalid //go:pure: %s", reason)
+		return nil
+	}
+
+	if n, ok := pureEvalCacheGet(fn, args); ok {
+		return n
+	}
+
+	if n := interpretPureCall(fn, args); n != nil {
+		if base.Flag.LowerM > 0 {
+			fmt.Printf("compile-time evaluated %s in-process\n", ir.PkgFuncName(fn))
+		}
+		pureEvalCachePut(fn, args, n)
+		return n
+	}
+
+	if base.Flag.LowerM > 1 {
+		fmt.Printf("could not evaluate %s in-process, falling back to helper program\n", ir.PkgFuncName(fn))
+	}
+
 	programSource := generateHelperProgram(fn, args)
 	if programSource == "" {
 		if base.Flag.LowerM > 1 {
@@ -57,6 +89,7 @@ func evaluatePureFunctionAtCompileTime(fn *ir.Func, args []ir.Node) ir.Node {
 	s := ir.NewString(base.Pos, result)
 	s.SetType(types.Types[types.TSTRING])
 	s.SetTypecheck(1)
+	pureEvalCachePut(fn, args, s)
 	return s
 }
 
@@ -66,19 +99,11 @@ func generateHelperProgram(fn *ir.Func, args []ir.Node) string {
 
 	var argExprs []string
 	for _, arg := range args {
-		val := arg.Val()
-		switch val.Kind() {
-		case constant.String:
-			argExprs = append(argExprs, fmt.Sprintf("%q", constant.StringVal(val)))
-		case constant.Int:
-			argExprs = append(argExprs, val.String())
-		case constant.Float:
-			argExprs = append(argExprs, val.String())
-		case constant.Bool:
-			argExprs = append(argExprs, fmt.Sprintf("%t", constant.BoolVal(val)))
-		default:
+		expr, ok := renderArgLiteral(arg, arg.Type())
+		if !ok {
 			return ""
 		}
+		argExprs = append(argExprs, expr)
 	}
 
 	var buf bytes.Buffer
@@ -116,23 +141,53 @@ func executeHelperProgram(source string) string {
 		}
 	}
 
-	cmd := exec.Command(goBin, "run", mainFile)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
+	ctx, cancel := context.WithTimeout(context.Background(), pureEvalTimeout())
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, goBin, "run", mainFile)
+	setHelperProcessGroup(cmd)
+	// On Unix, Cancel sends SIGKILL to the whole process group instead
+	// of just the go run wrapper, so a timeout actually reaps the
+	// compiled helper binary too, not just its parent.
+	cmd.Cancel = func() error { return killHelperProcessGroup(cmd) }
+	cmd.Env = scrubbedHelperEnv(buildcfg.GOROOT, buildcfg.GOOS, buildcfg.GOARCH)
+
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return ""
+	}
+	var stderr bytes.Buffer
 	cmd.Stderr = &stderr
 
-	// Set environment with the correct GOROOT and recursion guard
-	env := os.Environ()
-	if buildcfg.GOROOT != "" {
-		env = append(env, "GOROOT="+buildcfg.GOROOT)
+	if err := cmd.Start(); err != nil {
+		return ""
 	}
-	// Prevent recursive evaluation
-	env = append(env, "GO_PURE_EVAL_HELPER=1")
-	cmd.Env = env
 
-	if err := cmd.Run(); err != nil {
+	// Read at most maxHelperOutputBytes+1 so we can tell a genuinely
+	// truncated result (the +1th byte present) from one that happened
+	// to come out exactly at the limit, without ever buffering more
+	// than that much of a runaway helper's output.
+	var stdout bytes.Buffer
+	n, _ := io.Copy(&stdout, io.LimitReader(stdoutPipe, maxHelperOutputBytes+1))
+	truncated := n > maxHelperOutputBytes
+
+	waitErr := cmd.Wait()
+
+	if truncated {
+		if base.Flag.LowerM > 0 {
+			fmt.Printf("pure-eval: helper program output exceeded %d bytes, discarding\n", maxHelperOutputBytes)
+		}
+		return ""
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		if base.Flag.LowerM > 0 {
+			fmt.Printf("pure-eval: helper program timed out after %s\n", pureEvalTimeout())
+		}
+		return ""
+	}
+	if waitErr != nil {
 		if base.Flag.LowerM > 1 {
-			fmt.Printf("helper program error: %v\nstderr: %s\n", err, stderr.String())
+			fmt.Printf("helper program error: %v\nstderr: %s\n", waitErr, stderr.String())
 		}
 		return ""
 	}
@@ -140,39 +195,16 @@ func executeHelperProgram(source string) string {
 	return stdout.String()
 }
 
+// allArgsConstant reports whether every argument is constant-foldable:
+// a plain basic-kind constant, or - an array, slice, or struct
+// composite literal built entirely out of such constants, recursively.
 func allArgsConstant(args []ir.Node, paramTypes []*types.Field) bool {
 	if len(args) != len(paramTypes) {
 		return false
 	}
 
 	for i, arg := range args {
-		param := paramTypes[i]
-
-		// Check if argument is a constant
-		if arg.Op() != ir.OLITERAL {
-			return false
-		}
-
-		val := arg.Val()
-		kind := val.Kind()
-
-		// Only support basic constant types
-		switch kind {
-		case constant.String, constant.Int, constant.Float, constant.Bool:
-			// Supported
-		default:
-			return false
-		}
-
-		// Verify type compatibility
-		switch {
-		case param.Type.IsString() && kind != constant.String:
-			return false
-		case param.Type.IsInteger() && kind != constant.Int:
-			return false
-		case param.Type.IsFloat() && kind != constant.Float:
-			return false
-		case param.Type.IsBoolean() && kind != constant.Bool:
+		if !isConstantArg(arg, paramTypes[i].Type) {
 			return false
 		}
 	}
@@ -0,0 +1,392 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"go/constant"
+	"go/token"
+
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/ir"
+	"cmd/compile/internal/types"
+)
+
+// interpretPureCall evaluates a call to a //go:pure function entirely
+// in-process, without shelling out to a helper program. It walks the
+// function's already-typechecked IR (the local body, or the inline copy
+// serialized into export data for a cross-package call) treating params
+// as bound to the constant argument values, and folds straight-line
+// arithmetic, comparisons, and simple if/return control flow.
+//
+// It returns nil, meaning "don't know", whenever the body uses a
+// construct the interpreter doesn't model (loops, indexing or field
+// selection on composite values, calls to functions whose IR isn't
+// available). Callers are expected to
+// fall back to a slower path in that case.
+func interpretPureCall(fn *ir.Func, args []ir.Node) ir.Node {
+	return interpretPureCallDepth(fn, args, 0)
+}
+
+// interpretPureCallDepth is interpretPureCall with an explicit
+// recursion depth, so that a chain of //go:pure functions calling each
+// other accumulates against maxPureInterpDepth across the whole chain
+// rather than resetting to zero at every nested call.
+func interpretPureCallDepth(fn *ir.Func, args []ir.Node, depth int) ir.Node {
+	if depth >= maxPureInterpDepth {
+		return nil
+	}
+
+	body := fn.Body
+	if len(body) == 0 && fn.Inl != nil {
+		body = fn.Inl.Body
+	}
+	if len(body) == 0 {
+		if base.Flag.LowerM > 1 {
+			base.Warn("pure-eval: no IR available for %s, cannot interpret in-process", ir.PkgFuncName(fn))
+		}
+		return nil
+	}
+
+	env := newPureInterp(fn, args, depth)
+	if env == nil {
+		return nil
+	}
+
+	results, status := env.run(body)
+	if status != runReturned {
+		return nil
+	}
+
+	return foldPureResults(fn, results)
+}
+
+// pureInterp is the evaluation state for a single in-process pure call:
+// a binding from the callee's parameters and locals to constant-folded
+// values (scalars, or composites thereof - see pureValue).
+type pureInterp struct {
+	fn    *ir.Func
+	vars  map[*ir.Name]pureValue
+	depth int
+}
+
+// maxPureInterpDepth bounds recursive pure-to-pure calls so a
+// self-referential //go:pure function can't hang the compiler.
+const maxPureInterpDepth = 32
+
+func newPureInterp(fn *ir.Func, args []ir.Node, depth int) *pureInterp {
+	params := fn.Dcl
+	if len(params) < len(args) {
+		return nil
+	}
+
+	env := &pureInterp{fn: fn, depth: depth, vars: make(map[*ir.Name]pureValue, len(args))}
+	for i, arg := range args {
+		v, ok := constCompositeValue(arg)
+		if !ok {
+			return nil
+		}
+		env.vars[params[i]] = v
+	}
+	return env
+}
+
+// runStatus distinguishes why run stopped, since "the taken if-branch
+// didn't return" and "we hit something we can't interpret" both leave
+// run with no values in hand but must be handled completely
+// differently by the caller: the former means keep executing the
+// statements after the if, the latter means give up on the whole call.
+type runStatus int
+
+const (
+	runFallthrough runStatus = iota // block finished without a return; caller should continue
+	runReturned                     // a return statement was taken; vals holds its results
+	runUnsupported                  // hit a construct the interpreter doesn't model; abort
+)
+
+// run executes a statement list and reports what happened: a taken
+// return (with its folded values), falling off the end of the list
+// having only executed assignments and non-terminal ifs, or hitting
+// something the interpreter can't model.
+func (p *pureInterp) run(stmts []ir.Node) ([]pureValue, runStatus) {
+	for _, stmt := range stmts {
+		switch n := stmt.(type) {
+		case *ir.ReturnStmt:
+			vals := make([]pureValue, len(n.Results))
+			for i, r := range n.Results {
+				v, ok := p.eval(r)
+				if !ok {
+					return nil, runUnsupported
+				}
+				vals[i] = v
+			}
+			return vals, runReturned
+
+		case *ir.AssignStmt:
+			name, ok := n.X.(*ir.Name)
+			if !ok {
+				return nil, runUnsupported
+			}
+			v, ok := p.eval(n.Y)
+			if !ok {
+				return nil, runUnsupported
+			}
+			p.vars[name] = v
+
+		case *ir.IfStmt:
+			cond, ok := p.eval(n.Cond)
+			if !ok || cond.isComposite() || cond.scalar.Kind() != constant.Bool {
+				return nil, runUnsupported
+			}
+
+			branch := n.Else
+			if constant.BoolVal(cond.scalar) {
+				branch = n.Body
+			}
+			vals, status := p.run(branch)
+			if status != runFallthrough {
+				// The taken branch returned or hit something
+				// unsupported; either way that's this block's outcome
+				// too - propagate it instead of falling through to
+				// the statements after the if.
+				return vals, status
+			}
+			// The taken branch ran out of statements without
+			// returning (the common `if cond { ... }` guard-clause
+			// shape with no else), so execution continues with
+			// whatever follows the if in the enclosing block.
+
+		default:
+			// Declarations are skipped: the declared name has no
+			// binding until assigned, so reading it first fails eval.
+			// Anything else we don't recognize stops in-process
+			// interpretation rather than risk silently computing the
+			// wrong answer.
+			if _, isDecl := stmt.(*ir.Decl); isDecl {
+				continue
+			}
+			return nil, runUnsupported
+		}
+	}
+	return nil, runFallthrough
+}
+
+// eval folds an expression to a pureValue given the current variable
+// bindings, or reports ok=false if it can't.
+func (p *pureInterp) eval(n ir.Node) (pureValue, bool) {
+	switch n := n.(type) {
+	case *ir.BasicLit:
+		return scalarValue(n.Val()), true
+
+	case *ir.Name:
+		v, ok := p.vars[n]
+		return v, ok
+
+	case *ir.CompLitExpr:
+		return constCompositeValue(n)
+
+	case *ir.UnaryExpr:
+		x, ok := p.eval(n.X)
+		if !ok || x.isComposite() {
+			return pureValue{}, false
+		}
+		tok, ok := unaryOpToken(n.Op())
+		if !ok {
+			return pureValue{}, false
+		}
+		return scalarValue(constant.UnaryOp(tok, x.scalar, 0)), true
+
+	case *ir.BinaryExpr:
+		x, ok := p.eval(n.X)
+		if !ok || x.isComposite() {
+			return pureValue{}, false
+		}
+		y, ok := p.eval(n.Y)
+		if !ok || y.isComposite() {
+			return pureValue{}, false
+		}
+		return p.evalBinary(n.Op(), x.scalar, y.scalar)
+
+	case *ir.ParenExpr:
+		return p.eval(n.X)
+
+	case *ir.CallExpr:
+		return p.evalPureCall(n)
+
+	default:
+		return pureValue{}, false
+	}
+}
+
+func (p *pureInterp) evalBinary(op ir.Op, x, y constant.Value) (pureValue, bool) {
+	if tok, ok := compareOpToken(op); ok {
+		return scalarValue(constant.MakeBool(constant.Compare(x, tok, y))), true
+	}
+	tok, ok := binaryOpToken(op)
+	if !ok {
+		return pureValue{}, false
+	}
+	if (tok == token.QUO || tok == token.REM) && constant.Sign(y) == 0 {
+		// constant.BinaryOp panics on division by zero.
+		return pureValue{}, false
+	}
+	if tok == token.QUO && x.Kind() == constant.Int && y.Kind() == constant.Int {
+		// Integer division truncates, as Go's / does on integers;
+		// token.QUO would yield an exact rational instead.
+		tok = token.QUO_ASSIGN
+	}
+	return scalarValue(constant.BinaryOp(x, tok, y)), true
+}
+
+// evalPureCall recurses into a call of another //go:pure function whose
+// arguments are all themselves constant-foldable in this environment.
+// The nested call is interpreted at p.depth+1, so maxPureInterpDepth
+// bounds the whole chain of //go:pure calls, not just this one hop of
+// it - a self-referential pure function can't recurse past the limit
+// no matter how many real Go call frames that takes.
+func (p *pureInterp) evalPureCall(call *ir.CallExpr) (pureValue, bool) {
+	if p.depth+1 >= maxPureInterpDepth {
+		return pureValue{}, false
+	}
+
+	callee, ok := ir.StaticValue(call.X).(*ir.Name)
+	if !ok || callee.Func == nil || callee.Func.Pragma&ir.Pure == 0 {
+		return pureValue{}, false
+	}
+
+	args := make([]ir.Node, len(call.Args))
+	for i, a := range call.Args {
+		v, ok := p.eval(a)
+		if !ok {
+			return pureValue{}, false
+		}
+		args[i] = valueToNode(v, a.Type())
+	}
+
+	sub := interpretPureCallDepth(callee.Func, args, p.depth+1)
+	if sub == nil {
+		return pureValue{}, false
+	}
+
+	return nodeToValue(sub)
+}
+
+// nodeToValue is the inverse of valueToNode, recovering a pureValue
+// from a folded literal or composite-literal ir.Node.
+func nodeToValue(n ir.Node) (pureValue, bool) {
+	if n.Op() == ir.OLITERAL {
+		return scalarValue(n.Val()), true
+	}
+	cl, ok := n.(*ir.CompLitExpr)
+	if !ok {
+		return pureValue{}, false
+	}
+	elems := make([]pureValue, len(cl.List))
+	for i, e := range cl.List {
+		v, ok := nodeToValue(e)
+		if !ok {
+			return pureValue{}, false
+		}
+		elems[i] = v
+	}
+	return pureValue{elems: elems}, true
+}
+
+// foldPureResults converts the interpreter's pureValue results back
+// into typed ir.Nodes, handling the common single-result case and
+// multi-value returns by packing the rest into fn's own *types.Type
+// results tuple (the same Kind-TRESULTS type the real call already
+// carries) rather than inventing a new type for the occasion.
+func foldPureResults(fn *ir.Func, vals []pureValue) ir.Node {
+	results := fn.Type().Results()
+	if len(vals) != results.NumFields() {
+		return nil
+	}
+
+	if len(vals) == 1 {
+		return valueToNode(vals[0], results.Field(0).Type)
+	}
+
+	lits := make([]ir.Node, len(vals))
+	for i, v := range vals {
+		lits[i] = valueToNode(v, results.Field(i).Type)
+	}
+	cl := ir.NewCompLitExpr(base.Pos, ir.OCOMPLIT, results, lits)
+	cl.SetTypecheck(1)
+	return cl
+}
+
+// constToLit builds a typed literal ir.Node for a folded constant.Value.
+func constToLit(v constant.Value, typ *types.Type) ir.Node {
+	var n ir.Node
+	switch v.Kind() {
+	case constant.String:
+		n = ir.NewString(base.Pos, constant.StringVal(v))
+	case constant.Bool:
+		n = ir.NewBool(base.Pos, constant.BoolVal(v))
+	case constant.Int, constant.Float:
+		n = ir.NewBasicLit(base.Pos, v)
+	default:
+		return nil
+	}
+	n.SetType(typ)
+	n.SetTypecheck(1)
+	return n
+}
+
+func unaryOpToken(op ir.Op) (token.Token, bool) {
+	switch op {
+	case ir.ONEG:
+		return token.SUB, true
+	case ir.ONOT:
+		return token.NOT, true
+	case ir.OBITNOT:
+		return token.XOR, true
+	}
+	return token.ILLEGAL, false
+}
+
+func binaryOpToken(op ir.Op) (token.Token, bool) {
+	switch op {
+	case ir.OADD:
+		return token.ADD, true
+	case ir.OSUB:
+		return token.SUB, true
+	case ir.OMUL:
+		return token.MUL, true
+	case ir.ODIV:
+		return token.QUO, true
+	case ir.OMOD:
+		return token.REM, true
+	case ir.OAND:
+		return token.AND, true
+	case ir.OOR:
+		return token.OR, true
+	case ir.OXOR:
+		return token.XOR, true
+	case ir.OANDAND:
+		return token.LAND, true
+	case ir.OOROR:
+		return token.LOR, true
+	}
+	return token.ILLEGAL, false
+}
+
+func compareOpToken(op ir.Op) (token.Token, bool) {
+	switch op {
+	case ir.OEQ:
+		return token.EQL, true
+	case ir.ONE:
+		return token.NEQ, true
+	case ir.OLT:
+		return token.LSS, true
+	case ir.OLE:
+		return token.LEQ, true
+	case ir.OGT:
+		return token.GTR, true
+	case ir.OGE:
+		return token.GEQ, true
+	}
+	return token.ILLEGAL, false
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"go/constant"
+	"go/token"
+	"testing"
+
+	"cmd/compile/internal/base"
+	"cmd/compile/internal/ir"
+)
+
+func TestBinaryOpToken(t *testing.T) {
+	tests := []struct {
+		op   ir.Op
+		want token.Token
+	}{
+		{ir.OADD, token.ADD},
+		{ir.OMUL, token.MUL},
+		{ir.OANDAND, token.LAND},
+	}
+	for _, tt := range tests {
+		got, ok := binaryOpToken(tt.op)
+		if !ok || got != tt.want {
+			t.Errorf("binaryOpToken(%v) = %v, %v, want %v, true", tt.op, got, ok, tt.want)
+		}
+	}
+	if _, ok := binaryOpToken(ir.OEQ); ok {
+		t.Error("binaryOpToken(OEQ) should fail - comparisons go through compareOpToken")
+	}
+}
+
+func TestCompareOpToken(t *testing.T) {
+	tests := []struct {
+		op   ir.Op
+		want token.Token
+	}{
+		{ir.OEQ, token.EQL},
+		{ir.OLT, token.LSS},
+		{ir.OGE, token.GEQ},
+	}
+	for _, tt := range tests {
+		got, ok := compareOpToken(tt.op)
+		if !ok || got != tt.want {
+			t.Errorf("compareOpToken(%v) = %v, %v, want %v, true", tt.op, got, ok, tt.want)
+		}
+	}
+}
+
+func TestUnaryOpToken(t *testing.T) {
+	if got, ok := unaryOpToken(ir.ONEG); !ok || got != token.SUB {
+		t.Errorf("unaryOpToken(ONEG) = %v, %v, want %v, true", got, ok, token.SUB)
+	}
+	if _, ok := unaryOpToken(ir.OADD); ok {
+		t.Error("unaryOpToken(OADD) should fail - OADD isn't a unary operator this interpreter models")
+	}
+}
+
+// TestRunGuardClauseFallsThrough pins the bug the review caught: an
+// if-statement with no else that doesn't return must let execution
+// continue with whatever follows it in the enclosing block, rather
+// than ending interpretation of the whole call right there.
+func TestRunGuardClauseFallsThrough(t *testing.T) {
+	cond := ir.NewBool(base.Pos, false)
+	guard := ir.NewIfStmt(base.Pos, cond, nil, nil)
+
+	ret := ir.NewReturnStmt(base.Pos, []ir.Node{ir.NewBool(base.Pos, true)})
+
+	p := &pureInterp{vars: map[*ir.Name]pureValue{}}
+	vals, status := p.run([]ir.Node{guard, ret})
+
+	if status != runReturned {
+		t.Fatalf("run() status = %v, want runReturned", status)
+	}
+	if len(vals) != 1 || vals[0].scalar == nil || !constant.BoolVal(vals[0].scalar) {
+		t.Fatalf("run() after a skipped guard clause should still reach the final return, got %v", vals)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"os"
+	"time"
+
+	"cmd/compile/internal/base"
+)
+
+// defaultPureEvalTimeout bounds how long the out-of-process helper
+// program fallback is allowed to run. A //go:pure body that hangs (or
+// was wrongly verified as pure and actually blocks) would otherwise
+// hang the compile forever.
+const defaultPureEvalTimeout = 5 * time.Second
+
+// maxHelperOutputBytes caps how much stdout the helper program can
+// produce. Without this, a pure function body like `for { print("x") }`
+// could run the compiler out of memory before the timeout even fires.
+const maxHelperOutputBytes = 1 << 20 // 1 MiB
+
+// pureEvalTimeout returns the configured helper-program timeout,
+// honoring the -d=pureevaltimeout=N escape hatch (N in seconds).
+func pureEvalTimeout() time.Duration {
+	if base.Debug.PureEvalTimeout > 0 {
+		return time.Duration(base.Debug.PureEvalTimeout) * time.Second
+	}
+	return defaultPureEvalTimeout
+}
+
+// scrubbedHelperEnv builds the environment the helper program runs
+// under. The helper executes arbitrary code from the //go:pure
+// function body, so it gets a minimal environment rather than
+// inheriting the developer's: no HOME, no credentials, no proxy
+// configuration, just what the go tool itself needs to run hermetically.
+//
+// goos and goarch are always set explicitly to the actual compilation
+// target (buildcfg.GOOS/GOARCH), not left to default from the host
+// environment: without this, a cross-compile's fallback to the helper
+// program would silently evaluate the callee under the host's arch
+// instead of the target's, defeating the whole point of partitioning
+// the result cache by target GOOS/GOARCH in the first place.
+func scrubbedHelperEnv(goroot, goos, goarch string) []string {
+	env := []string{
+		"PATH=/usr/bin:/bin",
+		"GO_PURE_EVAL_HELPER=1",
+		"GOOS=" + goos,
+		"GOARCH=" + goarch,
+		// Force the helper to run hermetically: no network module
+		// fetches, and no writes back into the shared module cache.
+		"GOFLAGS=-mod=readonly -trimpath",
+	}
+	if goroot != "" {
+		env = append(env, "GOROOT="+goroot)
+	}
+	if v := os.Getenv("GOPATH"); v != "" {
+		env = append(env, "GOPATH="+v)
+	}
+	if v := os.Getenv("GOCACHE"); v != "" {
+		env = append(env, "GOCACHE="+v)
+	}
+	return env
+}
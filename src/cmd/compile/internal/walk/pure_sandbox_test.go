@@ -0,0 +1,83 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package walk
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"cmd/compile/internal/base"
+)
+
+func TestPureEvalTimeoutDefault(t *testing.T) {
+	base.Debug.PureEvalTimeout = 0
+	if got := pureEvalTimeout(); got != defaultPureEvalTimeout {
+		t.Errorf("pureEvalTimeout() = %v, want default %v", got, defaultPureEvalTimeout)
+	}
+}
+
+func TestPureEvalTimeoutOverride(t *testing.T) {
+	base.Debug.PureEvalTimeout = 30
+	defer func() { base.Debug.PureEvalTimeout = 0 }()
+
+	want := 30 * time.Second
+	if got := pureEvalTimeout(); got != want {
+		t.Errorf("pureEvalTimeout() with -d=pureevaltimeout=30 = %v, want %v", got, want)
+	}
+}
+
+func TestScrubbedHelperEnvDropsAmbientVars(t *testing.T) {
+	os.Setenv("SOME_SECRET", "hunter2")
+	defer os.Unsetenv("SOME_SECRET")
+
+	env := scrubbedHelperEnv("/goroot", "linux", "arm64")
+	for _, kv := range env {
+		if kv == "SOME_SECRET=hunter2" || kv == "HOME="+os.Getenv("HOME") {
+			t.Errorf("scrubbedHelperEnv leaked ambient environment: %q", kv)
+		}
+	}
+
+	for _, want := range []string{"GOROOT=/goroot", "GOOS=linux", "GOARCH=arm64"} {
+		found := false
+		for _, kv := range env {
+			if kv == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("scrubbedHelperEnv(\"/goroot\", \"linux\", \"arm64\") missing %q; got %v", want, env)
+		}
+	}
+}
+
+// TestScrubbedHelperEnvSetsCrossCompileTarget pins the review-flagged
+// bug: the helper's GOOS/GOARCH must follow the compilation target,
+// not whatever the host happens to default to, or a fallback to the
+// helper during a cross-compile would silently evaluate the callee for
+// the wrong platform.
+func TestScrubbedHelperEnvSetsCrossCompileTarget(t *testing.T) {
+	env := scrubbedHelperEnv("/goroot", "windows", "386")
+	for _, want := range []string{"GOOS=windows", "GOARCH=386"} {
+		found := false
+		for _, kv := range env {
+			if kv == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("scrubbedHelperEnv with target windows/386 missing %q; got %v", want, env)
+		}
+	}
+}
+
+func TestScrubbedHelperEnvOmitsGorootWhenEmpty(t *testing.T) {
+	env := scrubbedHelperEnv("", "linux", "amd64")
+	for _, kv := range env {
+		if len(kv) >= 7 && kv[:7] == "GOROOT=" {
+			t.Errorf("scrubbedHelperEnv(\"\", ...) should omit GOROOT, got %q", kv)
+		}
+	}
+}
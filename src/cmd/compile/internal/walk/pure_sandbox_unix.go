@@ -0,0 +1,24 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package walk
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setHelperProcessGroup puts the helper process in its own process
+// group, so that killing it on timeout (via cmd.Cancel, which sends
+// the process group rather than just the one pid) also reaps any
+// children it spawned, e.g. if the //go:pure body itself shells out.
+func setHelperProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+func killHelperProcessGroup(cmd *exec.Cmd) error {
+	return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,20 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build windows
+
+package walk
+
+import "os/exec"
+
+// setHelperProcessGroup is a no-op on Windows: Setpgid has no Windows
+// equivalent, and the helper is not placed in a separate process group.
+func setHelperProcessGroup(cmd *exec.Cmd) {}
+
+// killHelperProcessGroup kills only the go run process itself, via
+// Process.Kill; processes it started, such as the compiled helper
+// binary, are not killed.
+func killHelperProcessGroup(cmd *exec.Cmd) error {
+	return cmd.Process.Kill()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+//go:pure
+//go:noinline
+func firstByte(b []byte) byte {
+	return b[0]
+}
+
+//go:pure
+//go:noinline
+func equalBytes(a, b []byte) bool {
+	return bytes.Equal(a, b)
+}
+
+func main() {
+	// A composite constant argument: the compiler attempts compile-time
+	// evaluation of this call. The in-process interpreter does not model
+	// indexing, so firstByte falls back to the helper program.
+	r1 := firstByte([]byte{0x41, 0x42, 0x43})
+	// []byte("abc") is a conversion, not a composite literal, so this
+	// call's arguments are not constant and it stays an ordinary call.
+	r2 := equalBytes([]byte("abc"), []byte("abc"))
+
+	fmt.Printf("firstByte([]byte{0x41, 0x42, 0x43}) = %d\n", r1)
+	fmt.Printf("equalBytes(\"abc\", \"abc\") = %t\n", r2)
+
+	// Non-constant slice - still a function call.
+	data := []byte{1, 2, 3}
+	r3 := firstByte(data)
+	fmt.Printf("firstByte(data) = %d\n", r3)
+}